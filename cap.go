@@ -0,0 +1,156 @@
+package irc
+
+import (
+	"strings"
+	"sync"
+)
+
+// capState tracks IRCv3 capability negotiation (the CAP subcommands) for a
+// Client: which capabilities the server advertises, and which have been
+// enabled via CAP REQ/ACK.
+type capState struct {
+	mtx        sync.Mutex
+	advertised map[string]string
+	enabled    map[string]bool
+}
+
+// newCapState creates an empty capState.
+func newCapState() *capState {
+	return &capState{
+		advertised: make(map[string]string),
+		enabled:    make(map[string]bool),
+	}
+}
+
+// Caps returns the capabilities advertised by the server via CAP LS, keyed
+// by name with their (possibly empty) value.
+func (client *Client) Caps() map[string]string {
+	client.caps.mtx.Lock()
+	defer client.caps.mtx.Unlock()
+	caps := make(map[string]string, len(client.caps.advertised))
+	for name, value := range client.caps.advertised {
+		caps[name] = value
+	}
+	return caps
+}
+
+// CapEnabled reports whether the named capability was successfully
+// negotiated by a prior call to CapReq.
+func (client *Client) CapEnabled(name string) bool {
+	client.caps.mtx.Lock()
+	defer client.caps.mtx.Unlock()
+	return client.caps.enabled[name]
+}
+
+// CapReq negotiates IRCv3 capabilities with the server: it sends
+// "CAP LS 302", records every capability the server advertises, requests
+// the given caps with "CAP REQ", and waits for the server's ACK or NAK. It
+// must be called after Dial/DialTLS and before Nick, and before Listen is
+// started, since it reads directly from the connection.
+//
+// Unless the "sasl" capability is among those negotiated, CapReq also sends
+// "CAP END" to unblock registration. If "sasl" was negotiated, the caller
+// must authenticate with SASL and then call CapEnd.
+func (client *Client) CapReq(caps ...string) error {
+	if err := client.writer.PrintfLine("CAP LS 302"); err != nil {
+		return err
+	}
+	if err := client.readCapLS(); err != nil {
+		return err
+	}
+	if len(caps) > 0 {
+		if err := client.writer.PrintfLine("CAP REQ :%v", strings.Join(caps, " ")); err != nil {
+			return err
+		}
+		if err := client.readCapAck(); err != nil {
+			return err
+		}
+	}
+	if client.CapEnabled("sasl") {
+		return nil
+	}
+	return client.CapEnd()
+}
+
+// CapEnd finishes capability negotiation, unblocking registration
+// (NICK/USER). Callers only need to call it explicitly when the "sasl"
+// capability was negotiated by CapReq, once SASL authentication completes.
+func (client *Client) CapEnd() error {
+	return client.writer.PrintfLine("CAP END")
+}
+
+// readCapLS reads CAP LS responses until the final (non-continuation) line
+// is seen, recording every advertised capability and its value.
+func (client *Client) readCapLS() error {
+	for {
+		msg, err := client.readMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Cmd != "CAP" || len(msg.Params) < 3 || msg.Params[1] != "LS" {
+			continue
+		}
+		list := msg.Params[len(msg.Params)-1]
+		more := len(msg.Params) == 4 && msg.Params[2] == "*"
+		client.recordCaps(list)
+		if !more {
+			return nil
+		}
+	}
+}
+
+// readCapAck reads a single CAP ACK or NAK response to a prior CAP REQ,
+// recording which of the requested capabilities were enabled, and removing
+// any whose names were "-"-prefixed to request disabling them.
+func (client *Client) readCapAck() error {
+	for {
+		msg, err := client.readMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Cmd != "CAP" || len(msg.Params) < 3 {
+			continue
+		}
+		switch msg.Params[1] {
+		case "ACK":
+			client.caps.mtx.Lock()
+			for _, name := range strings.Fields(msg.Params[2]) {
+				if strings.HasPrefix(name, "-") {
+					delete(client.caps.enabled, strings.TrimPrefix(name, "-"))
+				} else {
+					client.caps.enabled[name] = true
+				}
+			}
+			client.caps.mtx.Unlock()
+			return nil
+		case "NAK":
+			return nil
+		}
+	}
+}
+
+// recordCaps parses a space-separated CAP LS capability list (each entry
+// optionally suffixed with "=value") into the client's advertised set.
+func (client *Client) recordCaps(list string) {
+	client.caps.mtx.Lock()
+	defer client.caps.mtx.Unlock()
+	for _, entry := range strings.Fields(list) {
+		nameAndValue := strings.SplitN(entry, "=", 2)
+		value := ""
+		if len(nameAndValue) == 2 {
+			value = nameAndValue[1]
+		}
+		client.caps.advertised[nameAndValue[0]] = value
+	}
+}
+
+// readMsg reads and parses a single line directly from the connection,
+// bypassing the recv channel used by Listen. It is only safe to call before
+// Listen starts its read loop.
+func (client *Client) readMsg() (*Msg, error) {
+	line, err := client.reader.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	return ParseMsg(line)
+}
@@ -0,0 +1,99 @@
+package irc
+
+import (
+	"bufio"
+	"net"
+	"testing"
+)
+
+func TestCapReqNegotiation(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	client := newClient(clientConn)
+	defer clientConn.Close()
+
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		server := bufio.NewReadWriter(bufio.NewReader(serverConn), bufio.NewWriter(serverConn))
+
+		readLine(t, server) // CAP LS 302
+		writeLine(t, server, "CAP * LS * :multi-prefix sasl")
+		writeLine(t, server, "CAP * LS :server-time")
+
+		readLine(t, server) // CAP REQ :sasl server-time
+		writeLine(t, server, "CAP * ACK :sasl server-time")
+	}()
+
+	if err := client.CapReq("sasl", "server-time"); err != nil {
+		t.Fatalf("CapReq: %v", err)
+	}
+	<-serverDone
+
+	caps := client.Caps()
+	if _, ok := caps["multi-prefix"]; !ok {
+		t.Errorf("Caps() = %v, missing multi-prefix", caps)
+	}
+	if _, ok := caps["server-time"]; !ok {
+		t.Errorf("Caps() = %v, missing server-time", caps)
+	}
+	if !client.CapEnabled("sasl") {
+		t.Error("CapEnabled(sasl) = false after ACK")
+	}
+	if !client.CapEnabled("server-time") {
+		t.Error("CapEnabled(server-time) = false after ACK")
+	}
+}
+
+func TestCapReqNegotiationRemoval(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	client := newClient(clientConn)
+	defer clientConn.Close()
+	client.caps.enabled["server-time"] = true
+
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		server := bufio.NewReadWriter(bufio.NewReader(serverConn), bufio.NewWriter(serverConn))
+
+		readLine(t, server) // CAP LS 302
+		writeLine(t, server, "CAP * LS :sasl")
+
+		readLine(t, server) // CAP REQ :sasl -server-time
+		writeLine(t, server, "CAP * ACK :sasl -server-time")
+	}()
+
+	if err := client.CapReq("sasl", "-server-time"); err != nil {
+		t.Fatalf("CapReq: %v", err)
+	}
+	<-serverDone
+
+	if !client.CapEnabled("sasl") {
+		t.Error("CapEnabled(sasl) = false after ACK")
+	}
+	if client.CapEnabled("server-time") {
+		t.Error("CapEnabled(server-time) = true after ACK of a \"-server-time\" removal")
+	}
+}
+
+func readLine(t *testing.T, rw *bufio.ReadWriter) string {
+	t.Helper()
+	line, err := rw.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString: %v", err)
+	}
+	return line
+}
+
+func writeLine(t *testing.T, rw *bufio.ReadWriter, line string) {
+	t.Helper()
+	if _, err := rw.WriteString(line + "\r\n"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+}
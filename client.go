@@ -1,15 +1,18 @@
 /*
-   Package irc provides a framework for writing IRC clients, specifically bots.
+Package irc provides a framework for writing IRC clients, specifically bots.
 */
 package irc
 
 import (
 	"bufio"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/textproto"
+	"sync"
+	"time"
 )
 
 // MsgHandler handles messages and optionally sends responses on chan send.
@@ -18,31 +21,102 @@ type Handler interface {
 	Handle(msg *Msg, send chan<- *Msg)
 }
 
+// minBackoff and maxBackoff bound the exponential backoff Listen applies
+// between reconnect attempts.
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// dialFunc establishes a fresh connection, for use by Listen when
+// reconnecting.
+type dialFunc func() (io.ReadWriteCloser, error)
+
 // Client is an IRC connection which handles message dispatch to a MsgHandler.
 type Client struct {
-	conn     io.ReadWriteCloser
-	writer   *textproto.Writer
-	reader   *textproto.Reader
-	send     chan *Msg
-	recv     chan *Msg
-	handlers []Handler
+	conn        io.ReadWriteCloser
+	writer      *textproto.Writer
+	reader      *textproto.Reader
+	send        chan *Msg
+	sendHigh    chan *Msg
+	sendLow     chan *Msg
+	bucketMtx   sync.Mutex
+	bucket      *tokenBucket
+	recv        chan *Msg
+	handlers    []Handler
+	caps        *capState
+	dial        dialFunc
+	onConnMtx   sync.Mutex
+	onConnect   []func(*Client)
+	isupportMtx sync.Mutex
+	isupport    ISupport
+	keepalive   *keepaliveState
 }
 
-// Dial connects to an IRC host.
+// Dial connects to an IRC host in plaintext.
 func Dial(address string) (*Client, error) {
-	conn, err := net.Dial("tcp", address)
+	return dialClient(func() (io.ReadWriteCloser, error) {
+		return net.Dial("tcp", address)
+	})
+}
+
+// DialTLS connects to an IRC host over TLS.
+func DialTLS(address string, cfg *tls.Config) (*Client, error) {
+	return dialClient(func() (io.ReadWriteCloser, error) {
+		return tls.Dial("tcp", address, cfg)
+	})
+}
+
+// dialClient opens the initial connection with dial and wraps it in a
+// Client, retaining dial so Listen can reconnect later.
+func dialClient(dial dialFunc) (*Client, error) {
+	conn, err := dial()
 	if err != nil {
 		return nil, err
 	}
-	client := Client{
-		conn:     conn,
-		writer:   textproto.NewWriter(bufio.NewWriter(conn)),
-		reader:   textproto.NewReader(bufio.NewReader(conn)),
-		send:     make(chan *Msg, 5),
-		recv:     make(chan *Msg, 5),
-		handlers: make([]Handler, 0),
+	client := newClient(conn)
+	client.dial = dial
+	return client, nil
+}
+
+// newClient wraps an established connection in a Client.
+func newClient(conn io.ReadWriteCloser) *Client {
+	return &Client{
+		conn:      conn,
+		writer:    textproto.NewWriter(bufio.NewWriter(conn)),
+		reader:    textproto.NewReader(bufio.NewReader(conn)),
+		send:      make(chan *Msg, sendQueueSize),
+		sendHigh:  make(chan *Msg, sendQueueSize),
+		sendLow:   make(chan *Msg, sendQueueSize),
+		bucket:    newTokenBucket(defaultSendRate, defaultSendBurst),
+		recv:      make(chan *Msg, 5),
+		handlers:  make([]Handler, 0),
+		caps:      newCapState(),
+		keepalive: &keepaliveState{lastRecv: time.Now()},
+	}
+}
+
+// OnConnect registers a hook to be run synchronously by Listen for each
+// connection: once for the initial Dial/DialTLS connection when Listen
+// starts, and again after every reconnect Listen performs. Use it to (re-)issue CAP
+// negotiation, SASL, NICK/USER and channel joins so a bot resumes cleanly
+// after a dropped connection.
+func (client *Client) OnConnect(hook func(*Client)) {
+	client.onConnMtx.Lock()
+	defer client.onConnMtx.Unlock()
+	client.onConnect = append(client.onConnect, hook)
+}
+
+// fireOnConnect runs every hook registered with OnConnect, in registration
+// order.
+func (client *Client) fireOnConnect() {
+	client.onConnMtx.Lock()
+	hooks := make([]func(*Client), len(client.onConnect))
+	copy(hooks, client.onConnect)
+	client.onConnMtx.Unlock()
+	for _, hook := range hooks {
+		hook(client)
 	}
-	return &client, nil
 }
 
 // Register registers a handler for dispatch.
@@ -50,46 +124,152 @@ func (client *Client) Register(handler Handler) {
 	client.handlers = append(client.handlers, handler)
 }
 
-//
+// Nick sets our nick and registers with the server (NICK/USER), queued at
+// PriorityHigh like other connection-registration traffic.
 func (client *Client) Nick(user string) {
-	client.writer.PrintfLine("NICK %v", user)
-	client.writer.PrintfLine("USER %v %v %v :%v", user)
+	client.Send(&Msg{Cmd: "NICK", Params: []string{user}}, PriorityHigh)
+	client.Send(&Msg{Cmd: "USER", Params: []string{user, "0", "*", user}}, PriorityHigh)
 }
 
+// Join joins a channel, queued at PriorityHigh like other
+// connection-registration traffic.
 func (client *Client) Join(channel string) {
-	client.writer.PrintfLine("JOIN %v", channel)
+	client.Send(&Msg{Cmd: "JOIN", Params: []string{channel}}, PriorityHigh)
 }
 
-// Listen puts an irc connection into a loop, parsing and dispatching recieved
-// messages to a handler, as well as sending outgoing messages.
+// Listen puts an irc connection into a loop, parsing and dispatching
+// recieved messages to a handler, as well as sending outgoing messages. If
+// the connection is dialed (via Dial/DialTLS), Listen supervises it:
+// on disconnect it reconnects with exponential backoff (from minBackoff,
+// doubling up to maxBackoff, reset after a successful RPL_WELCOME), running
+// every OnConnect hook again on each new connection.
 func (client *Client) Listen() {
-	go func() {
+	backoff := minBackoff
+	client.fireOnConnect()
+	for {
+		if err := client.listenOnce(&backoff); err != nil {
+			log.Println(err)
+		}
+		if client.dial == nil {
+			return
+		}
 		for {
-			line, err := client.reader.ReadLine()
-			if err != nil {
+			time.Sleep(backoff)
+			if backoff < maxBackoff {
+				backoff *= 2
+				if backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+			}
+			if err := client.reconnect(); err != nil {
 				log.Println(err)
 				continue
 			}
-			msg, err := ParseMsg(line)
+			break
+		}
+		client.fireOnConnect()
+	}
+}
+
+// listenOnce runs a single connection's read/dispatch loop, and a separate
+// rate-limited send loop, until either fails, returning the resulting
+// error. *backoff is reset to minBackoff once the server confirms
+// registration with RPL_WELCOME (001). The send loop runs independently so
+// a slow or blocked write can never stall dispatch of received messages.
+//
+// Before returning, listenOnce closes client.conn (unblocking any pending
+// read) and waits for the read, send and keepalive goroutines to exit, so
+// the caller can safely mutate client.conn/reader/writer (e.g. to
+// reconnect) the moment listenOnce returns.
+func (client *Client) listenOnce(backoff *time.Duration) error {
+	errc := make(chan error, 3)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(3)
+	defer func() {
+		close(done)
+		client.conn.Close()
+		wg.Wait()
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			msg, err := client.readMsg()
 			if err != nil {
-				log.Println(err)
-				continue
+				select {
+				case errc <- err:
+				case <-done:
+				}
+				return
+			}
+			select {
+			case client.recv <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := client.sendLoop(done); err != nil {
+			select {
+			case errc <- err:
+			case <-done:
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := client.keepaliveLoop(done); err != nil {
+			select {
+			case errc <- err:
+			case <-done:
 			}
-			client.recv <- msg
 		}
 	}()
 	for {
 		select {
+		case err := <-errc:
+			return err
 		case msg := <-client.recv:
 			fmt.Printf("[log:recv] %v\n", msg)
+			client.touchKeepalive()
+			if msg.Cmd == "PING" {
+				client.Send(&Msg{Cmd: "PONG", Params: msg.Params}, PriorityHigh)
+			}
+			if n, ok := msg.Numeric(); ok {
+				switch n {
+				case RPL_WELCOME:
+					*backoff = minBackoff
+				case RPL_ISUPPORT:
+					client.updateISupport(msg)
+				}
+			}
 			for _, handler := range client.handlers {
 				if handler.Accept(msg) {
 					go handler.Handle(msg, client.send)
 				}
 			}
-		case msg := <-client.send:
-			fmt.Printf("[log:send] %v\n", msg)
-			client.writer.PrintfLine("%v", msg)
 		}
 	}
 }
+
+// reconnect establishes a fresh connection using the dialFunc captured at
+// Dial/DialTLS time, replacing the client's connection, reader and writer.
+// It also discards any capability/ISUPPORT state learned from the previous
+// connection, since a new connection negotiates these from scratch and the
+// new server isn't guaranteed to match the old one.
+func (client *Client) reconnect() error {
+	conn, err := client.dial()
+	if err != nil {
+		return err
+	}
+	client.conn = conn
+	client.writer = textproto.NewWriter(bufio.NewWriter(conn))
+	client.reader = textproto.NewReader(bufio.NewReader(conn))
+	client.caps = newCapState()
+	client.isupportMtx.Lock()
+	client.isupport = ISupport{}
+	client.isupportMtx.Unlock()
+	return nil
+}
@@ -7,6 +7,9 @@ import (
 	"sync"
 )
 
+// CmdHandler implements Handler so it can be passed to Client.Register.
+var _ Handler = (*CmdHandler)(nil)
+
 // cmdResponseWriter is a simple writer that abstracts away the Msg struct.
 type cmdResponseWriter struct {
 	send     chan<- *Msg
@@ -43,8 +46,8 @@ func NewCmdHandler(prefix string) *CmdHandler {
 	return &CmdHandler{prefix: prefix, cmds: make(map[string]Cmd)}
 }
 
-// Accepts for a CmdHandler ensures the msg contains a chat command.
-func (c *CmdHandler) Accepts(msg *Msg) bool {
+// Accept for a CmdHandler ensures the msg contains a chat command.
+func (c *CmdHandler) Accept(msg *Msg) bool {
 	isPrivmsg := msg.Cmd == "PRIVMSG"
 	hasCmdPrefix := len(msg.Params) == 2 &&
 		strings.HasPrefix(msg.Params[1], c.prefix)
@@ -0,0 +1,116 @@
+package irc
+
+import (
+	"io"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ctcpDelim frames a CTCP command within a PRIVMSG/NOTICE body.
+const ctcpDelim = "\x01"
+
+// CTCP responds to incoming CTCP commands (PING, VERSION, ACTION, ...).
+type CTCP interface {
+	Respond(source, args string, w io.Writer)
+}
+
+// A CTCPFunc responds to incoming CTCP commands.
+type CTCPFunc func(source, args string, w io.Writer)
+
+func (f CTCPFunc) Respond(source, args string, w io.Writer) {
+	f(source, args, w)
+}
+
+// ctcpFrame wraps cmd/args in the \x01 framing a CTCP payload requires.
+func ctcpFrame(cmd, args string) string {
+	body := ctcpDelim + cmd
+	if args != "" {
+		body += " " + args
+	}
+	return body + ctcpDelim
+}
+
+// ctcpResponseWriter composes a CTCP reply as a NOTICE back to the sender.
+type ctcpResponseWriter struct {
+	send   chan<- *Msg
+	source string
+	cmd    string
+}
+
+func (w ctcpResponseWriter) Write(p []byte) (int, error) {
+	w.send <- &Msg{Cmd: "NOTICE", Params: []string{w.source, ctcpFrame(w.cmd, string(p))}}
+	return len(p), nil
+}
+
+// A CTCPHandler dispatches CTCP commands found inside PRIVMSG/NOTICE
+// payloads, analogous to CmdHandler for regular chat commands. CTCP ACTION
+// (i.e. "/me") is dispatched like any other registered command, so it can
+// be handled uniformly by registering "ACTION".
+type CTCPHandler struct {
+	cmdsMtx sync.Mutex
+	cmds    map[string]CTCP
+}
+
+// CTCPHandler implements Handler so it can be passed to Client.Register.
+var _ Handler = (*CTCPHandler)(nil)
+
+// NewCTCPHandler creates an empty CTCPHandler.
+func NewCTCPHandler() *CTCPHandler {
+	return &CTCPHandler{cmds: make(map[string]CTCP)}
+}
+
+// Accept for a CTCPHandler ensures the msg carries a CTCP payload.
+func (c *CTCPHandler) Accept(msg *Msg) bool {
+	isChat := msg.Cmd == "PRIVMSG" || msg.Cmd == "NOTICE"
+	return isChat && len(msg.Params) == 2 && isCTCP(msg.Params[1])
+}
+
+// isCTCP reports whether body is framed as a CTCP payload.
+func isCTCP(body string) bool {
+	return len(body) >= 2 &&
+		strings.HasPrefix(body, ctcpDelim) && strings.HasSuffix(body, ctcpDelim)
+}
+
+// Handle for a CTCPHandler extracts the CTCP command and args and dispatches
+// to a CTCP, if one is found with the given name.
+func (c *CTCPHandler) Handle(msg *Msg, send chan<- *Msg) {
+	payload := strings.Trim(msg.Params[1], ctcpDelim)
+	nameAndArgs := strings.SplitN(payload, " ", 2)
+	name := nameAndArgs[0]
+	args := ""
+	if len(nameAndArgs) > 1 {
+		args = nameAndArgs[1]
+	}
+	source, err := msg.ExtractNick()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c.cmdsMtx.Lock()
+	ctcp, ok := c.cmds[name]
+	c.cmdsMtx.Unlock()
+	if ok {
+		go ctcp.Respond(source, args, ctcpResponseWriter{send: send, source: source, cmd: name})
+	}
+}
+
+// Register adds a CTCP to be executed when the given command name is
+// matched.
+func (c *CTCPHandler) Register(name string, ctcp CTCP) {
+	c.cmdsMtx.Lock()
+	defer c.cmdsMtx.Unlock()
+	c.cmds[name] = ctcp
+}
+
+// RegisterFunc adds a CTCPFunc to be executed when the given command name
+// is matched.
+func (c *CTCPHandler) RegisterFunc(name string, ctcpFunc CTCPFunc) {
+	c.Register(name, CTCP(ctcpFunc))
+}
+
+// CTCPReply sends a CTCP reply to target, framed as a NOTICE with \x01
+// delimiters, per the CTCP specification.
+func (client *Client) CTCPReply(target, cmd, args string) {
+	client.send <- &Msg{Cmd: "NOTICE", Params: []string{target, ctcpFrame(cmd, args)}}
+}
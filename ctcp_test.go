@@ -0,0 +1,91 @@
+package irc
+
+import (
+	"io"
+	"testing"
+)
+
+func TestCTCPFrame(t *testing.T) {
+	if got, want := ctcpFrame("VERSION", ""), "\x01VERSION\x01"; got != want {
+		t.Errorf("ctcpFrame(VERSION, \"\") = %q, want %q", got, want)
+	}
+	if got, want := ctcpFrame("PING", "123456"), "\x01PING 123456\x01"; got != want {
+		t.Errorf("ctcpFrame(PING, 123456) = %q, want %q", got, want)
+	}
+}
+
+func TestCTCPHandlerAccept(t *testing.T) {
+	c := NewCTCPHandler()
+	accepted := &Msg{Cmd: "PRIVMSG", Params: []string{"#chan", "\x01VERSION\x01"}}
+	if !c.Accept(accepted) {
+		t.Error("Accept() = false for a framed CTCP PRIVMSG")
+	}
+	plain := &Msg{Cmd: "PRIVMSG", Params: []string{"#chan", "hello"}}
+	if c.Accept(plain) {
+		t.Error("Accept() = true for a plain PRIVMSG")
+	}
+	other := &Msg{Cmd: "JOIN", Params: []string{"#chan"}}
+	if c.Accept(other) {
+		t.Error("Accept() = true for a non-chat command")
+	}
+}
+
+func TestCTCPHandlerDispatch(t *testing.T) {
+	c := NewCTCPHandler()
+	var gotSource, gotArgs string
+	c.RegisterFunc("PING", func(source, args string, w io.Writer) {
+		gotSource, gotArgs = source, args
+		w.Write([]byte(args))
+	})
+
+	send := make(chan *Msg, 1)
+	msg := &Msg{
+		Cmd:    "PRIVMSG",
+		Prefix: "alice!user@host",
+		Params: []string{"bot", "\x01PING 123456\x01"},
+	}
+	if !c.Accept(msg) {
+		t.Fatal("Accept() = false for a framed PING")
+	}
+	c.Handle(msg, send)
+
+	reply := <-send
+	if reply.Cmd != "NOTICE" {
+		t.Errorf("reply.Cmd = %q, want NOTICE", reply.Cmd)
+	}
+	if reply.Params[0] != "alice" {
+		t.Errorf("reply target = %q, want %q", reply.Params[0], "alice")
+	}
+	if want := "\x01PING 123456\x01"; reply.Params[1] != want {
+		t.Errorf("reply body = %q, want %q", reply.Params[1], want)
+	}
+	if gotSource != "alice" || gotArgs != "123456" {
+		t.Errorf("Respond called with (%q, %q), want (%q, %q)", gotSource, gotArgs, "alice", "123456")
+	}
+}
+
+// TestRegisterCTCPHandler exercises a CTCPHandler the way Listen's dispatch
+// loop does: via Client.Register and the Handler interface, not by calling
+// Accept/Handle directly. This catches CTCPHandler failing to satisfy
+// Handler (e.g. a misspelled Accept method), which direct calls wouldn't.
+func TestRegisterCTCPHandler(t *testing.T) {
+	client := newClient(&fakeConn{})
+	called := false
+	ctcp := NewCTCPHandler()
+	ctcp.RegisterFunc("VERSION", func(source, args string, w io.Writer) {
+		called = true
+		w.Write(nil)
+	})
+	client.Register(ctcp)
+
+	msg := &Msg{Cmd: "PRIVMSG", Prefix: "alice!u@h", Params: []string{"bot", "\x01VERSION\x01"}}
+	for _, handler := range client.handlers {
+		if handler.Accept(msg) {
+			handler.Handle(msg, client.send)
+		}
+	}
+	<-client.send // synchronize with the goroutine Handle spawns
+	if !called {
+		t.Error("VERSION CTCP handler registered via Client.Register was not invoked")
+	}
+}
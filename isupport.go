@@ -0,0 +1,45 @@
+package irc
+
+// ISupport holds the subset of RPL_ISUPPORT (005) tokens most commonly
+// needed by clients, such as for parsing mode strings and case-folding
+// nicks.
+type ISupport struct {
+	CaseMapping string
+	ChanModes   string
+	Prefix      string
+	ChanTypes   string
+	Network     string
+}
+
+// isupportTokens are the ISUPPORT keys tracked on ISupport, and the field
+// each is copied into.
+var isupportTokens = map[string]func(i *ISupport, value string){
+	"CASEMAPPING": func(i *ISupport, value string) { i.CaseMapping = value },
+	"CHANMODES":   func(i *ISupport, value string) { i.ChanModes = value },
+	"PREFIX":      func(i *ISupport, value string) { i.Prefix = value },
+	"CHANTYPES":   func(i *ISupport, value string) { i.ChanTypes = value },
+	"NETWORK":     func(i *ISupport, value string) { i.Network = value },
+}
+
+// ISupport returns the server's most recently announced ISUPPORT values.
+func (client *Client) ISupport() ISupport {
+	client.isupportMtx.Lock()
+	defer client.isupportMtx.Unlock()
+	return client.isupport
+}
+
+// updateISupport merges the tokens from an RPL_ISUPPORT message into the
+// client's tracked ISupport.
+func (client *Client) updateISupport(msg *Msg) {
+	tokens, err := ParseISUPPORT(msg)
+	if err != nil {
+		return
+	}
+	client.isupportMtx.Lock()
+	defer client.isupportMtx.Unlock()
+	for key, value := range tokens {
+		if set, ok := isupportTokens[key]; ok {
+			set(&client.isupport, value)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package irc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// keepaliveMissedLimit is how many consecutive missed PING responses
+// Client tolerates before closing the connection, triggering Listen's
+// reconnect logic.
+const keepaliveMissedLimit = 3
+
+// keepaliveState tracks idle-based PING/PONG liveness checking.
+type keepaliveState struct {
+	mtx      sync.Mutex
+	interval time.Duration
+	timeout  time.Duration
+	lastRecv time.Time
+}
+
+// SetKeepalive enables client-initiated keepalive: once the read side has
+// been idle for interval, Client sends a PING and expects some traffic
+// within timeout. After keepaliveMissedLimit consecutive misses, the
+// connection is closed, triggering Listen's reconnect logic. Pass
+// interval <= 0 to disable (the default).
+func (client *Client) SetKeepalive(interval, timeout time.Duration) {
+	client.keepalive.mtx.Lock()
+	defer client.keepalive.mtx.Unlock()
+	client.keepalive.interval = interval
+	client.keepalive.timeout = timeout
+}
+
+// touchKeepalive records that traffic was just received, resetting the idle
+// clock consulted by keepaliveLoop.
+func (client *Client) touchKeepalive() {
+	client.keepalive.mtx.Lock()
+	defer client.keepalive.mtx.Unlock()
+	client.keepalive.lastRecv = time.Now()
+}
+
+// keepaliveLoop pings the server after read-side idle periods and reports
+// an error once the server stops responding, so Listen can reconnect. It
+// runs until done is closed. The interval is read once, when the loop
+// starts; if it is not positive, the loop is a no-op for the whole
+// connection, even if SetKeepalive is called later.
+func (client *Client) keepaliveLoop(done <-chan struct{}) error {
+	client.keepalive.mtx.Lock()
+	interval := client.keepalive.interval
+	client.keepalive.mtx.Unlock()
+	if interval <= 0 {
+		<-done
+		return nil
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+		}
+		client.keepalive.mtx.Lock()
+		idle := time.Since(client.keepalive.lastRecv)
+		timeout := client.keepalive.timeout
+		client.keepalive.mtx.Unlock()
+		if idle < interval {
+			missed = 0
+			continue
+		}
+		client.Send(&Msg{Cmd: "PING", Params: []string{"keepalive"}}, PriorityHigh)
+		select {
+		case <-done:
+			return nil
+		case <-time.After(timeout):
+		}
+		client.keepalive.mtx.Lock()
+		responded := time.Since(client.keepalive.lastRecv) < timeout
+		client.keepalive.mtx.Unlock()
+		if responded {
+			missed = 0
+			continue
+		}
+		missed++
+		if missed >= keepaliveMissedLimit {
+			// Close the connection directly rather than relying solely on
+			// the caller to tear it down: a truly stuck socket must stop
+			// appearing open the moment liveness is declared lost.
+			client.conn.Close()
+			return fmt.Errorf("keepalive: no response after %d missed PINGs", missed)
+		}
+	}
+}
@@ -14,11 +14,100 @@ var (
 // to-be-sent IRC message.
 type Msg struct {
 	Raw    string
+	Tags   map[string]string
 	Prefix string
 	Cmd    string
 	Params []string
 }
 
+// tagEscapes maps unescaped tag value runes to their escaped form, as
+// defined by the IRCv3 message-tags specification.
+var tagEscapes = map[rune]string{
+	';':  `\:`,
+	' ':  `\s`,
+	'\\': `\\`,
+	'\r': `\r`,
+	'\n': `\n`,
+}
+
+// tagUnescapes maps escaped tag value sequences back to their original
+// rune.
+var tagUnescapes = map[rune]rune{
+	':':  ';',
+	's':  ' ',
+	'\\': '\\',
+	'r':  '\r',
+	'n':  '\n',
+}
+
+// escapeTagValue escapes a tag value for transmission on the wire.
+func escapeTagValue(value string) (escaped string) {
+	for _, r := range value {
+		if rep, ok := tagEscapes[r]; ok {
+			escaped += rep
+		} else {
+			escaped += string(r)
+		}
+	}
+	return
+}
+
+// unescapeTagValue reverses escapeTagValue, as applied by a peer when
+// sending us a tag value. A trailing, dangling backslash is dropped per
+// the spec.
+func unescapeTagValue(value string) (unescaped string) {
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			if rep, ok := tagUnescapes[runes[i+1]]; ok {
+				unescaped += string(rep)
+				i++
+				continue
+			}
+		}
+		if runes[i] != '\\' {
+			unescaped += string(runes[i])
+		}
+	}
+	return
+}
+
+// parseTags parses an IRCv3 tag string (everything between the leading "@"
+// and the first space) into a tag map.
+func parseTags(raw string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(raw, ";") {
+		if tag == "" {
+			continue
+		}
+		keyAndValue := strings.SplitN(tag, "=", 2)
+		key := keyAndValue[0]
+		value := ""
+		if len(keyAndValue) == 2 {
+			value = unescapeTagValue(keyAndValue[1])
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
+// tagsString formats the tag map back into wire format, including the
+// leading "@". It returns the empty string if there are no tags.
+func (msg *Msg) tagsString() string {
+	if len(msg.Tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(msg.Tags))
+	for key, value := range msg.Tags {
+		if value == "" {
+			pairs = append(pairs, key)
+		} else {
+			pairs = append(pairs, key+"="+escapeTagValue(value))
+		}
+	}
+	return "@" + strings.Join(pairs, ";") + " "
+}
+
 // paramsString formats the parameter list, handling the trailing edge case.
 func (msg *Msg) paramsString() (str string) {
 	for i, param := range msg.Params {
@@ -34,8 +123,8 @@ func (msg *Msg) paramsString() (str string) {
 
 // String converts the Msg struct to an IRC message string.
 func (msg Msg) String() string {
-	return fmt.Sprintf(":%v %v %v",
-		msg.Prefix, msg.Cmd, msg.paramsString())
+	return fmt.Sprintf("%v:%v %v %v",
+		msg.tagsString(), msg.Prefix, msg.Cmd, msg.paramsString())
 }
 
 // ParseMsg accepts a raw IRC message string and parses it into a Msg struct.
@@ -44,10 +133,23 @@ func ParseMsg(raw string) (*Msg, error) {
 	if msg.Raw == "" {
 		return nil, ErrBadMsg
 	}
+	raw = msg.Raw
+	// Tags are optional, and must be of the form '@tag1=value;tag2 rest'.
+	if strings.HasPrefix(raw, "@") {
+		tagsAndRest := strings.SplitN(raw, " ", 2)
+		if len(tagsAndRest) != 2 {
+			return nil, ErrBadMsg
+		}
+		msg.Tags = parseTags(strings.TrimPrefix(tagsAndRest[0], "@"))
+		raw = tagsAndRest[1]
+	}
 	// Prefix is optional, but must be of the form ':prefix rest'.
 	if strings.HasPrefix(raw, ":") {
 		// Break the string up into a prefix and "the rest".
 		prefixAndRest := strings.SplitN(raw, " ", 2)
+		if len(prefixAndRest) != 2 {
+			return nil, ErrBadMsg
+		}
 		msg.Prefix = strings.TrimLeft(prefixAndRest[0], ":")
 		raw = prefixAndRest[1]
 	}
@@ -0,0 +1,47 @@
+package irc
+
+import "testing"
+
+func TestParseMsgTags(t *testing.T) {
+	msg, err := ParseMsg("@time=2023-01-02T15:04:05.000Z;id=123 :nick!user@host PRIVMSG #chan :hi")
+	if err != nil {
+		t.Fatalf("ParseMsg: %v", err)
+	}
+	if msg.Tags["time"] != "2023-01-02T15:04:05.000Z" {
+		t.Errorf("Tags[time] = %q", msg.Tags["time"])
+	}
+	if msg.Tags["id"] != "123" {
+		t.Errorf("Tags[id] = %q", msg.Tags["id"])
+	}
+	if msg.Prefix != "nick!user@host" {
+		t.Errorf("Prefix = %q", msg.Prefix)
+	}
+}
+
+func TestParseMsgTagEscaping(t *testing.T) {
+	msg, err := ParseMsg(`@note=a\sb\:c\\d\r\ne :nick PRIVMSG #chan :hi`)
+	if err != nil {
+		t.Fatalf("ParseMsg: %v", err)
+	}
+	want := "a b;c\\d\r\ne"
+	if msg.Tags["note"] != want {
+		t.Errorf("Tags[note] = %q, want %q", msg.Tags["note"], want)
+	}
+}
+
+func TestEscapeTagValueRoundTrip(t *testing.T) {
+	for _, value := range []string{"plain", "a;b c\\d\re\nf", ""} {
+		escaped := escapeTagValue(value)
+		if got := unescapeTagValue(escaped); got != value {
+			t.Errorf("unescapeTagValue(escapeTagValue(%q)) = %q", value, got)
+		}
+	}
+}
+
+func TestParseMsgMalformedDoesNotPanic(t *testing.T) {
+	for _, raw := range []string{"@tag=value", ":prefix-only", "@", ":"} {
+		if _, err := ParseMsg(raw); err == nil {
+			t.Errorf("ParseMsg(%q): want error, got nil", raw)
+		}
+	}
+}
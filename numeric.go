@@ -0,0 +1,84 @@
+package irc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Numeric identifies a numeric IRC reply or error, as defined by RFC 2812
+// and various IRCv3 extensions.
+type Numeric int
+
+// A sampling of the numeric replies and errors most commonly needed by
+// clients. Not exhaustive; see https://defs.ircdocs.horse/defs/numerics for
+// the full registry.
+const (
+	RPL_WELCOME    Numeric = 1
+	RPL_YOURHOST   Numeric = 2
+	RPL_CREATED    Numeric = 3
+	RPL_MYINFO     Numeric = 4
+	RPL_ISUPPORT   Numeric = 5
+	RPL_NAMREPLY   Numeric = 353
+	RPL_ENDOFNAMES Numeric = 366
+	RPL_MOTD       Numeric = 372
+	RPL_MOTDSTART  Numeric = 375
+	RPL_ENDOFMOTD  Numeric = 376
+
+	RPL_LOGGEDIN    Numeric = 900
+	RPL_SASLSUCCESS Numeric = 903
+
+	ERR_NICKNAMEINUSE Numeric = 433
+	ERR_SASLFAIL      Numeric = 904
+	ERR_SASLTOOLONG   Numeric = 905
+	ERR_SASLABORTED   Numeric = 906
+	ERR_SASLALREADY   Numeric = 907
+)
+
+// Numeric returns the Msg's command as a Numeric, and whether the command
+// was in fact numeric, as opposed to a named command like PRIVMSG.
+func (msg *Msg) Numeric() (Numeric, bool) {
+	n, err := strconv.Atoi(msg.Cmd)
+	if err != nil {
+		return 0, false
+	}
+	return Numeric(n), true
+}
+
+// ParseISUPPORT parses an RPL_ISUPPORT (005) message's parameters into a map
+// of ISUPPORT tokens to values, with valueless tokens mapping to "".
+func ParseISUPPORT(msg *Msg) (map[string]string, error) {
+	if n, ok := msg.Numeric(); !ok || n != RPL_ISUPPORT {
+		return nil, fmt.Errorf("not an RPL_ISUPPORT message: %v", msg)
+	}
+	if len(msg.Params) < 2 {
+		return nil, ErrBadMsg
+	}
+	tokens := make(map[string]string)
+	// The first param is our own nick and the last is a human-readable
+	// trailer ("are supported by this server"); everything between is a
+	// token, optionally of the form "KEY=VALUE".
+	for _, param := range msg.Params[1 : len(msg.Params)-1] {
+		keyAndValue := strings.SplitN(param, "=", 2)
+		value := ""
+		if len(keyAndValue) == 2 {
+			value = keyAndValue[1]
+		}
+		tokens[keyAndValue[0]] = value
+	}
+	return tokens, nil
+}
+
+// ParseNames parses an RPL_NAMREPLY (353) message into the channel it
+// describes and the list of nicks present.
+func ParseNames(msg *Msg) (channel string, nicks []string, err error) {
+	if n, ok := msg.Numeric(); !ok || n != RPL_NAMREPLY {
+		return "", nil, fmt.Errorf("not an RPL_NAMREPLY message: %v", msg)
+	}
+	if len(msg.Params) < 4 {
+		return "", nil, ErrBadMsg
+	}
+	channel = msg.Params[2]
+	nicks = strings.Fields(msg.Params[3])
+	return channel, nicks, nil
+}
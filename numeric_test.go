@@ -0,0 +1,61 @@
+package irc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMsgNumeric(t *testing.T) {
+	if n, ok := (&Msg{Cmd: "001"}).Numeric(); !ok || n != RPL_WELCOME {
+		t.Errorf("Numeric() = (%v, %v), want (%v, true)", n, ok, RPL_WELCOME)
+	}
+	if _, ok := (&Msg{Cmd: "PRIVMSG"}).Numeric(); ok {
+		t.Error("Numeric() on a named command reported ok=true")
+	}
+}
+
+func TestParseISUPPORT(t *testing.T) {
+	msg := &Msg{
+		Cmd: "005",
+		Params: []string{
+			"nick", "CASEMAPPING=rfc1459", "PREFIX=(ov)@+", "CHANTYPES=#&",
+			"are supported by this server",
+		},
+	}
+	tokens, err := ParseISUPPORT(msg)
+	if err != nil {
+		t.Fatalf("ParseISUPPORT: %v", err)
+	}
+	want := map[string]string{
+		"CASEMAPPING": "rfc1459",
+		"PREFIX":      "(ov)@+",
+		"CHANTYPES":   "#&",
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("ParseISUPPORT() = %v, want %v", tokens, want)
+	}
+	if _, err := ParseISUPPORT(&Msg{Cmd: "PRIVMSG"}); err == nil {
+		t.Error("ParseISUPPORT on a non-005 message did not error")
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	msg := &Msg{
+		Cmd:    "353",
+		Params: []string{"nick", "=", "#chan", "alice @bob +carol"},
+	}
+	channel, nicks, err := ParseNames(msg)
+	if err != nil {
+		t.Fatalf("ParseNames: %v", err)
+	}
+	if channel != "#chan" {
+		t.Errorf("channel = %q, want %q", channel, "#chan")
+	}
+	wantNicks := []string{"alice", "@bob", "+carol"}
+	if !reflect.DeepEqual(nicks, wantNicks) {
+		t.Errorf("nicks = %v, want %v", nicks, wantNicks)
+	}
+	if _, _, err := ParseNames(&Msg{Cmd: "PRIVMSG"}); err == nil {
+		t.Error("ParseNames on a non-353 message did not error")
+	}
+}
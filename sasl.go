@@ -0,0 +1,126 @@
+package irc
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// saslChunkSize is the maximum number of base64 bytes sent per AUTHENTICATE
+// line, per the IRCv3 SASL specification.
+const saslChunkSize = 400
+
+// SASLCred produces the raw (unencoded) authentication payload for a SASL
+// mechanism.
+type SASLCred interface {
+	Payload() []byte
+}
+
+// PlainCred authenticates via the SASL PLAIN mechanism.
+type PlainCred struct {
+	Authzid string
+	User    string
+	Pass    string
+}
+
+// Payload formats the PLAIN payload as "authzid\0user\0pass".
+func (cred PlainCred) Payload() []byte {
+	return []byte(cred.Authzid + "\x00" + cred.User + "\x00" + cred.Pass)
+}
+
+// ExternalCred authenticates via the SASL EXTERNAL mechanism, relying on an
+// out-of-band identity such as a client TLS certificate.
+type ExternalCred struct{}
+
+// Payload is empty for EXTERNAL; identity is established out-of-band.
+func (cred ExternalCred) Payload() []byte {
+	return []byte{}
+}
+
+// SASL performs IRCv3 SASL authentication using the given mechanism name
+// (e.g. "PLAIN" or "EXTERNAL") and credential. The "sasl" capability must
+// already be negotiated via CapReq, and SASL must be called before CapEnd.
+func (client *Client) SASL(mech string, cred SASLCred) error {
+	if err := client.writer.PrintfLine("AUTHENTICATE %v", mech); err != nil {
+		return err
+	}
+	if err := client.awaitAuthenticate(); err != nil {
+		return err
+	}
+	if err := client.sendSASLPayload(cred.Payload()); err != nil {
+		return err
+	}
+	return client.awaitSASLResult()
+}
+
+// sendSASLPayload base64-encodes payload and sends it as one or more
+// AUTHENTICATE lines, chunked to saslChunkSize bytes, with a trailing empty
+// chunk if the payload is empty or a multiple of saslChunkSize.
+func (client *Client) sendSASLPayload(payload []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(payload)
+	if encoded == "" {
+		return client.writer.PrintfLine("AUTHENTICATE +")
+	}
+	for len(encoded) > 0 {
+		end := saslChunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		chunk := encoded[:end]
+		if err := client.writer.PrintfLine("AUTHENTICATE %v", chunk); err != nil {
+			return err
+		}
+		encoded = encoded[end:]
+		if len(chunk) < saslChunkSize {
+			return nil
+		}
+	}
+	return client.writer.PrintfLine("AUTHENTICATE +")
+}
+
+// isSASLFailure reports whether n is one of the SASL failure numerics.
+func isSASLFailure(n Numeric) bool {
+	switch n {
+	case ERR_SASLFAIL, ERR_SASLTOOLONG, ERR_SASLABORTED, ERR_SASLALREADY:
+		return true
+	default:
+		return false
+	}
+}
+
+// awaitAuthenticate blocks until the server sends an AUTHENTICATE challenge
+// (typically "AUTHENTICATE +"), failing if SASL is aborted first.
+func (client *Client) awaitAuthenticate() error {
+	for {
+		msg, err := client.readMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Cmd == "AUTHENTICATE" {
+			return nil
+		}
+		if n, ok := msg.Numeric(); ok && isSASLFailure(n) {
+			return fmt.Errorf("SASL authentication failed: %v", msg)
+		}
+	}
+}
+
+// awaitSASLResult blocks until the server confirms (RPL_SASLSUCCESS) or
+// rejects SASL authentication.
+func (client *Client) awaitSASLResult() error {
+	for {
+		msg, err := client.readMsg()
+		if err != nil {
+			return err
+		}
+		n, ok := msg.Numeric()
+		if !ok {
+			continue
+		}
+		if n == RPL_SASLSUCCESS {
+			return nil
+		}
+		if isSASLFailure(n) {
+			return fmt.Errorf("SASL authentication failed: %v", msg)
+		}
+	}
+}
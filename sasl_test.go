@@ -0,0 +1,73 @@
+package irc
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// fakeConn is a minimal io.ReadWriteCloser backed by a buffer, for
+// exercising Client methods that write to the wire without a real network
+// connection.
+type fakeConn struct {
+	bytes.Buffer
+}
+
+func (f *fakeConn) Close() error { return nil }
+
+func TestSendSASLPayloadChunking(t *testing.T) {
+	conn := &fakeConn{}
+	client := newClient(conn)
+
+	// A payload whose base64 encoding is exactly one chunk (400 bytes)
+	// must still be followed by an empty "AUTHENTICATE +" to signal
+	// completion.
+	payload := bytes.Repeat([]byte("x"), 300) // base64 of 300 bytes = 400 chars
+	if err := client.sendSASLPayload(payload); err != nil {
+		t.Fatalf("sendSASLPayload: %v", err)
+	}
+
+	lines := readLines(t, &conn.Buffer)
+	if len(lines) != 2 {
+		t.Fatalf("got %d AUTHENTICATE lines, want 2: %v", len(lines), lines)
+	}
+	if len(lines[0]) != len("AUTHENTICATE ")+saslChunkSize {
+		t.Errorf("first chunk length = %d, want %d", len(lines[0])-len("AUTHENTICATE "), saslChunkSize)
+	}
+	if lines[1] != "AUTHENTICATE +" {
+		t.Errorf("final line = %q, want final empty-chunk marker", lines[1])
+	}
+}
+
+func TestSendSASLPayloadEmpty(t *testing.T) {
+	conn := &fakeConn{}
+	client := newClient(conn)
+	if err := client.sendSASLPayload(nil); err != nil {
+		t.Fatalf("sendSASLPayload: %v", err)
+	}
+	lines := readLines(t, &conn.Buffer)
+	if len(lines) != 1 || lines[0] != "AUTHENTICATE +" {
+		t.Errorf("lines = %v, want [\"AUTHENTICATE +\"]", lines)
+	}
+}
+
+func TestPlainCredPayload(t *testing.T) {
+	cred := PlainCred{User: "alice", Pass: "hunter2"}
+	want := "\x00alice\x00hunter2"
+	if got := string(cred.Payload()); got != want {
+		t.Errorf("Payload() = %q, want %q", got, want)
+	}
+}
+
+// readLines reads every newline-terminated line currently buffered,
+// trimming the trailing CRLF written by textproto.Writer.
+func readLines(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	scanner := bufio.NewScanner(buf)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines
+}
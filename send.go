@@ -0,0 +1,161 @@
+package irc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sendQueueSize bounds each priority's outbound queue; once full, Send
+// blocks the caller (backpressure) rather than growing without limit.
+const sendQueueSize = 64
+
+// defaultSendRate and defaultSendBurst mirror typical server SENDQ/flood
+// limits (e.g. 2 lines/sec with a burst of 5).
+const (
+	defaultSendRate  = 2.0
+	defaultSendBurst = 5
+)
+
+// Priority controls the order in which queued outbound messages are
+// written, within the limits of the client's token-bucket rate limiter.
+type Priority int
+
+const (
+	// PriorityHigh is for time-sensitive control traffic: PONG, CAP, NICK.
+	PriorityHigh Priority = iota
+	// PriorityNormal is for ordinary chat output, e.g. PRIVMSG replies.
+	PriorityNormal
+	// PriorityLow is for bulk or non-urgent output.
+	PriorityLow
+)
+
+// Send queues msg for delivery at the given priority. Handler/Cmd/CTCP
+// output sent on the channel passed to Handle is equivalent to
+// PriorityNormal.
+func (client *Client) Send(msg *Msg, priority Priority) {
+	switch priority {
+	case PriorityHigh:
+		client.sendHigh <- msg
+	case PriorityLow:
+		client.sendLow <- msg
+	default:
+		client.send <- msg
+	}
+}
+
+// SetSendRate reconfigures the token-bucket outbound rate limiter: rate
+// lines per second, allowing bursts up to burst lines. Safe to call while
+// Listen is running.
+func (client *Client) SetSendRate(rate float64, burst int) {
+	client.bucketMtx.Lock()
+	defer client.bucketMtx.Unlock()
+	client.bucket = newTokenBucket(rate, burst)
+}
+
+// getBucket returns the client's current token bucket, synchronized
+// against a concurrent SetSendRate.
+func (client *Client) getBucket() *tokenBucket {
+	client.bucketMtx.Lock()
+	defer client.bucketMtx.Unlock()
+	return client.bucket
+}
+
+// tokenBucket rate-limits a steady stream of events to rate per second,
+// allowing bursts up to max.
+type tokenBucket struct {
+	mtx      sync.Mutex
+	tokens   float64
+	max      float64
+	rate     float64
+	lastFill time.Time
+}
+
+// newTokenBucket creates a tokenBucket starting full, so the first burst
+// events succeed immediately.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		tokens:   float64(burst),
+		max:      float64(burst),
+		rate:     rate,
+		lastFill: time.Now(),
+	}
+}
+
+// take blocks until a token is available, consuming it, or until done is
+// closed, whichever comes first. It reports false if it gave up because of
+// done, so a slow configured rate can never outlast the connection it is
+// throttling.
+func (b *tokenBucket) take(done <-chan struct{}) bool {
+	for {
+		b.mtx.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.lastFill).Seconds() * b.rate
+		if b.tokens > b.max {
+			b.tokens = b.max
+		}
+		b.lastFill = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mtx.Unlock()
+			return true
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mtx.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-done:
+			timer.Stop()
+			return false
+		}
+	}
+}
+
+// sendLoop pulls queued outbound messages, highest priority first, and
+// writes them to the connection, rate limited by client.bucket so a burst
+// of handler output cannot trigger a server's excess-flood protection. It
+// runs until done is closed or a write fails.
+func (client *Client) sendLoop(done <-chan struct{}) error {
+	for {
+		msg, ok := client.nextOutgoing(done)
+		if !ok {
+			return nil
+		}
+		if !client.getBucket().take(done) {
+			return nil
+		}
+		fmt.Printf("[log:send] %v\n", msg)
+		if err := client.writer.PrintfLine("%v", msg); err != nil {
+			return err
+		}
+	}
+}
+
+// nextOutgoing returns the next queued outbound message, preferring
+// PriorityHigh over PriorityNormal over PriorityLow. It reports false if
+// done is closed before a message is available.
+func (client *Client) nextOutgoing(done <-chan struct{}) (*Msg, bool) {
+	select {
+	case msg := <-client.sendHigh:
+		return msg, true
+	default:
+	}
+	select {
+	case msg := <-client.sendHigh:
+		return msg, true
+	case msg := <-client.send:
+		return msg, true
+	default:
+	}
+	select {
+	case msg := <-client.sendHigh:
+		return msg, true
+	case msg := <-client.send:
+		return msg, true
+	case msg := <-client.sendLow:
+		return msg, true
+	case <-done:
+		return nil, false
+	}
+}